@@ -2,15 +2,17 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
-
-	"net/url"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/solo-io/unik/pkg/config"
@@ -18,8 +20,12 @@ import (
 	unikutil "github.com/solo-io/unik/pkg/util"
 )
 
-var daemonRuntimeFolder, daemonConfigFile, logFile string
-var debugMode, trace bool
+// unikVersion is overridden at build time via -ldflags.
+var unikVersion = "dev"
+
+var daemonRuntimeFolder, daemonConfigFile, logFile, logFormat string
+var debugMode, trace, rootless bool
+var logMaxSizeMB, logMaxBackups int
 
 // daemonCmd is the command to start the unik daemon process.
 // It requires docker to be installed and running on the system.
@@ -46,30 +52,72 @@ Example usage:
 	 # debug mode activated
 	 # trace mode activated
 	 # outputting logs to logs.txt
+
+	unik daemon --rootless
+
+	 # runs the daemon as the calling user: state under $XDG_RUNTIME_DIR/unik,
+	 # API served on a unix socket there instead of a TCP port, and any
+	 # provider requiring root (e.g. xen) refused at startup
+
+	unik daemon --log-format json --logfile unik.log --log-max-size-mb 50
+
+	 # emits JSON logs, correlation-id tagged per request, rotating
+	 # unik.log every 50MB
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 
+			// in rootless mode, state and sockets live under
+			// $XDG_RUNTIME_DIR/unik rather than $HOME/.unik, mirroring
+			// rootlesskit/rootless dockerd
+			if rootless && !cmd.Flags().Changed("d") {
+				runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+				if runtimeDir == "" {
+					return errors.New("--rootless requires XDG_RUNTIME_DIR to be set", nil)
+				}
+				daemonRuntimeFolder = filepath.Join(runtimeDir, "unik")
+			}
+
 			// set unik home
 			config.Internal.UnikHome = daemonRuntimeFolder
+			if err := os.MkdirAll(config.Internal.UnikHome, 0755); err != nil {
+				return errors.New("failed to create daemon runtime folder "+config.Internal.UnikHome, err)
+			}
 
 			if daemonConfigFile == "" {
 				daemonConfigFile = filepath.Join(config.Internal.UnikHome, "daemon-config.yaml")
 			}
 
-			if err := readDaemonConfig(); err != nil {
+			if err := readDaemonConfig(cmd.Flags().Lookup("rootless")); err != nil {
 				return err
 			}
 
-			//don't print vsphere password
-			redactions := []string{}
-			for _, vsphereConfig := range daemonConfig.Providers.Vsphere {
-				redactions = append(redactions, vsphereConfig.VspherePassword, url.QueryEscape(vsphereConfig.VspherePassword))
+			var innerFormatter logrus.Formatter
+			switch logFormat {
+			case "", "text":
+				innerFormatter = &logrus.TextFormatter{}
+			case "json":
+				innerFormatter = &logrus.JSONFormatter{}
+			default:
+				return errors.New(fmt.Sprintf("invalid --log-format %q, must be \"text\" or \"json\"", logFormat), nil)
 			}
+
+			// don't print provider secrets (vsphere password, etc) - any
+			// config field tagged `unik:"secret"` is redacted automatically.
+			// Redaction runs on the serialized bytes so it works the same
+			// under either formatter.
 			logrus.SetFormatter(&unikutil.RedactedTextFormatter{
-				Redactions: redactions,
+				Inner:      innerFormatter,
+				Redactions: secretRedactions(daemonConfig),
 			})
 
+			// attach daemon-level fields to every entry, machine-parseable
+			// when --log-format=json; fieldsHook is kept around so a
+			// config reload can refresh "provider" if the reload added or
+			// removed a provider block
+			fieldsHook := unikutil.NewStaticFieldsHook(daemonLogFields(daemonConfig))
+			logrus.AddHook(fieldsHook)
+
 			if debugMode {
 				logrus.SetLevel(logrus.DebugLevel)
 			}
@@ -77,12 +125,15 @@ Example usage:
 				logrus.AddHook(&unikutil.AddTraceHook{true})
 			}
 			if logFile != "" {
-				os.Create(logFile)
-				f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0777)
-				if err != nil {
-					return errors.New(fmt.Sprintf("failed to open log file %s for writing", logFile), err)
-				}
-				logrus.AddHook(&unikutil.TeeHook{f})
+				logrus.AddHook(&unikutil.TeeHook{Writer: &lumberjack.Logger{
+					Filename:   logFile,
+					MaxSize:    logMaxSizeMB,
+					MaxBackups: logMaxBackups,
+				}})
+			}
+
+			if reports := daemonConfig.Validate(); !printValidationReport(reports) {
+				return errors.New("daemon config failed validation, run 'unik daemon validate' for details", nil)
 			}
 
 			logrus.WithField("config", daemonConfig).Info("daemon started")
@@ -90,6 +141,29 @@ Example usage:
 			if err != nil {
 				return errors.New("daemon failed to initialize", err)
 			}
+
+			// reload provider credentials on config file changes without
+			// restarting the daemon; daemon.Reload just swaps the config
+			// under a mutex, so in-flight requests are unaffected either way
+			daemonViper.OnConfigChange(func(e fsnotify.Event) {
+				logrus.WithField("file", e.Name).Info("daemon config changed, reloading")
+				var reloaded config.DaemonConfig
+				if err := daemonViper.Unmarshal(&reloaded); err != nil {
+					logrus.Errorf("failed to reload daemon config: %v", err)
+					return
+				}
+				if err := d.Reload(reloaded); err != nil {
+					logrus.Errorf("failed to apply reloaded daemon config: %v", err)
+					return
+				}
+				logrus.SetFormatter(&unikutil.RedactedTextFormatter{
+					Inner:      innerFormatter,
+					Redactions: secretRedactions(reloaded),
+				})
+				fieldsHook.Set(daemonLogFields(reloaded))
+			})
+			daemonViper.WatchConfig()
+
 			d.Run(port)
 			return nil
 		}(); err != nil {
@@ -116,23 +190,181 @@ func init() {
 	daemonCmd.Flags().BoolVar(&trace, "trace", false, "<bool, optional> add stack trace to daemon logs")
 	// logFile flag specifies the output file for logs in addition to stdout.
 	daemonCmd.Flags().StringVar(&logFile, "logfile", "", "<string, optional> output logs to file (in addition to stdout)")
+	// rootless flag runs the daemon as an unprivileged user, rootlesskit-style:
+	// state moves under $XDG_RUNTIME_DIR/unik, the API binds a unix socket
+	// there instead of a TCP port, and providers requiring root are refused.
+	daemonCmd.Flags().BoolVar(&rootless, "rootless", false, "<bool, optional> run daemon unprivileged, rootlesskit-style (state and socket under $XDG_RUNTIME_DIR/unik)")
+	// logFormat flag switches log output between free-form text and JSON,
+	// the latter being machine-parseable for ingestion into ELK/Loki.
+	daemonCmd.Flags().StringVar(&logFormat, "log-format", "text", "<string, optional> log output format, \"text\" or \"json\"")
+	// logMaxSizeMB and logMaxBackups control size-based rotation of --logfile,
+	// so long-running daemons don't fill the disk.
+	daemonCmd.Flags().IntVar(&logMaxSizeMB, "log-max-size-mb", 100, "<int, optional> max size in MB of --logfile before it is rotated")
+	daemonCmd.Flags().IntVar(&logMaxBackups, "log-max-backups", 5, "<int, optional> number of rotated --logfile backups to retain")
 }
 
 var daemonConfig config.DaemonConfig
 
-// readDaemonConfig reads the daemon configuration file and unmarshals its content into the daemonConfig variable.
-// It returns an error if the file cannot be read or if the content is not valid YAML.
-func readDaemonConfig() error {
-	data, err := ioutil.ReadFile(daemonConfigFile)
-	if err != nil {
-		errMsg := fmt.Sprintf("failed to read daemon configuration file at " + daemonConfigFile + `\n
-		See documentation at http://github.com/emc-advanced-dev/unik for creating daemon config.'`)
-		return errors.New(errMsg, err)
+// daemonViper is the layered config source backing daemonConfig. It is
+// kept around (rather than discarded after readDaemonConfig returns) so
+// Run can register it for live-reload via WatchConfig.
+var daemonViper = viper.New()
+
+// readDaemonConfig builds the daemon configuration from, in order of
+// increasing precedence: /etc/unik/daemon-config.{yaml,yml,json,toml},
+// $UNIK_HOME/daemon-config.{yaml,yml,json,toml}, an explicit --f file, then the
+// UNIK_ROOTLESS env var, then the --rootless flag. It unmarshals the
+// merged result into daemonConfig.
+//
+// rootlessFlag is the --rootless *pflag.Flag to bind, or nil if the
+// calling command doesn't expose one (e.g. daemonValidateCmd). It's
+// passed in rather than looked up via the package-level daemonCmd var so
+// that daemonCmd's own initializer - whose Run closure calls this
+// function - never refers back to daemonCmd itself; doing so is a
+// Go initialization cycle, not just bad style.
+//
+// Only top-level scalar fields (currently just Rootless) can be bound to
+// an env var or flag this way - viper has no way to address a field
+// inside a slice element (e.g. a specific vsphere instance's password),
+// so per-provider credentials must come from one of the config file
+// layers.
+func readDaemonConfig(rootlessFlag *pflag.Flag) error {
+	if err := mergeConfigDir(daemonViper, "/etc/unik"); err != nil {
+		return err
+	}
+	if err := mergeConfigDir(daemonViper, config.Internal.UnikHome); err != nil {
+		return err
 	}
-	if err := yaml.Unmarshal(data, &daemonConfig); err != nil {
-		errMsg := fmt.Sprintf("failed to parse daemon configuration yaml at " + daemonConfigFile + `\n
-		Please ensure config file contains valid yaml.'`)
+	if daemonConfigFile != "" {
+		daemonViper.SetConfigFile(daemonConfigFile)
+		if err := daemonViper.MergeInConfig(); err != nil {
+			errMsg := fmt.Sprintf("failed to read daemon configuration file at " + daemonConfigFile + `\n
+			See documentation at http://github.com/emc-advanced-dev/unik for creating daemon config.'`)
+			return errors.New(errMsg, err)
+		}
+	}
+
+	daemonViper.SetEnvPrefix("unik")
+	daemonViper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	daemonViper.BindEnv("rootless")
+	if rootlessFlag != nil {
+		daemonViper.BindPFlag("rootless", rootlessFlag)
+	}
+
+	if err := daemonViper.Unmarshal(&daemonConfig); err != nil {
+		errMsg := fmt.Sprintf("failed to parse daemon configuration at " + daemonConfigFile + `\n
+		Please ensure config file contains valid yaml, json or toml.'`)
 		return errors.New(errMsg, err)
 	}
+
+	return nil
+}
+
+// mergeConfigDir merges dir/daemon-config.{yaml,yml,json,toml} (whichever
+// exists first) into v on top of whatever's already loaded, so each layer
+// only overrides the keys it actually sets rather than replacing the
+// whole config outright. A missing directory or file is not an error -
+// every layer is optional.
+func mergeConfigDir(v *viper.Viper, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	for _, ext := range []string{"yaml", "yml", "json", "toml"} {
+		path := filepath.Join(dir, "daemon-config."+ext)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		v.SetConfigFile(path)
+		if err := v.MergeInConfig(); err != nil {
+			return errors.New("failed to read daemon configuration at "+path, err)
+		}
+		return nil
+	}
 	return nil
 }
+
+// daemonValidateCmd parses a daemon config and runs every configured
+// provider's semantic validation, printing a pass/fail report per
+// provider. A vsphere block is checked for endpoint reachability only and
+// a xen block for a non-empty xen_bridge; referenced datastores and
+// credentials are not probed. It shares
+// daemonConfigFile with daemonCmd so `unik daemon validate --f x.yaml`
+// checks exactly the file `unik daemon --f x.yaml` would load.
+var daemonValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates a daemon config file without starting the daemon",
+	Long: `Parses the daemon config and runs semantic validation for every
+configured provider block (currently: reachability of the vsphere endpoint,
+and that a xen block names its bridge).
+Prints a pass/fail report per provider and exits non-zero if any provider
+fails validation.
+
+Example usage:
+	unik daemon validate --f ./my-config.yaml
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if daemonConfigFile == "" {
+			daemonConfigFile = filepath.Join(daemonRuntimeFolder, "daemon-config.yaml")
+		}
+		config.Internal.UnikHome = daemonRuntimeFolder
+
+		// daemonValidateCmd has no --rootless flag of its own (none of
+		// the checks validate runs depend on rootless)
+		if err := readDaemonConfig(nil); err != nil {
+			logrus.Errorf("%v", err)
+			os.Exit(-1)
+		}
+
+		if !printValidationReport(daemonConfig.Validate()) {
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonValidateCmd)
+	daemonValidateCmd.Flags().StringVar(&daemonConfigFile, "f", "", "daemon config file to validate (default is {RuntimeFolder}/daemon-config.yaml)")
+	daemonValidateCmd.Flags().StringVar(&daemonRuntimeFolder, "d", getHomeDir()+"/.unik/", "daemon runtime folder - where state is stored. (default is $HOME/.unik/)")
+}
+
+// printValidationReport prints one pass/fail line per provider report and
+// returns true only if every provider passed.
+func printValidationReport(reports []config.ValidationReport) bool {
+	ok := true
+	if len(reports) == 0 {
+		fmt.Println("no providers configured, nothing to validate")
+		return true
+	}
+	for _, r := range reports {
+		if r.Err != nil {
+			ok = false
+			fmt.Printf("FAIL %s: %v\n", r.Provider, r.Err)
+		} else {
+			fmt.Printf("PASS %s\n", r.Provider)
+		}
+	}
+	return ok
+}
+
+// secretRedactions returns every value that should be scrubbed from log
+// output for cfg - the value of each field tagged `unik:"secret"`, plus
+// its URL-query-escaped form since provider URLs often embed passwords.
+func secretRedactions(cfg config.DaemonConfig) []string {
+	var redactions []string
+	for _, secret := range config.Redactions(cfg) {
+		redactions = append(redactions, secret, url.QueryEscape(secret))
+	}
+	return redactions
+}
+
+// daemonLogFields builds the daemon-level fields attached to every log
+// entry. unik_version and pid never change for the process's lifetime,
+// but provider does whenever a config reload adds or removes a provider
+// block, so this is recomputed on every reload rather than captured once.
+func daemonLogFields(cfg config.DaemonConfig) logrus.Fields {
+	return logrus.Fields{
+		"unik_version": unikVersion,
+		"pid":          os.Getpid(),
+		"provider":     strings.Join(cfg.Providers.Names(), ","),
+	}
+}
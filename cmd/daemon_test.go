@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/solo-io/unik/pkg/config"
+)
+
+// resetDaemonConfigState gives each test a clean daemonViper/daemonConfig
+// and restores the package globals readDaemonConfig touches, since both
+// are shared mutable state across the whole cmd package.
+func resetDaemonConfigState(t *testing.T) {
+	t.Helper()
+	origViper := daemonViper
+	origConfig := daemonConfig
+	origConfigFile := daemonConfigFile
+	origUnikHome := config.Internal.UnikHome
+	origRootless := rootless
+
+	daemonViper = viper.New()
+	daemonConfig = config.DaemonConfig{}
+
+	t.Cleanup(func() {
+		daemonViper = origViper
+		daemonConfig = origConfig
+		daemonConfigFile = origConfigFile
+		config.Internal.UnikHome = origUnikHome
+		rootless = origRootless
+		daemonCmd.Flags().Set("rootless", "false")
+	})
+}
+
+func writeConfigFile(t *testing.T, dir, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, "daemon-config.yaml")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// TestReadDaemonConfig_Precedence checks that an explicit --f file
+// overrides $UNIK_HOME/daemon-config.yaml, and that the --rootless flag
+// overrides both, matching the precedence documented on readDaemonConfig.
+func TestReadDaemonConfig_Precedence(t *testing.T) {
+	resetDaemonConfigState(t)
+
+	unikHome, err := ioutil.TempDir("", "unik-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(unikHome)
+	writeConfigFile(t, unikHome, "rootless: false\n")
+
+	explicitDir, err := ioutil.TempDir("", "unik-explicit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(explicitDir)
+	explicitFile := writeConfigFile(t, explicitDir, "rootless: true\n")
+
+	config.Internal.UnikHome = unikHome
+	daemonConfigFile = explicitFile
+
+	if err := readDaemonConfig(daemonCmd.Flags().Lookup("rootless")); err != nil {
+		t.Fatalf("readDaemonConfig() error = %v", err)
+	}
+	if !daemonConfig.Rootless {
+		t.Fatalf("expected --f layer (rootless: true) to win over $UNIK_HOME layer, got Rootless=false")
+	}
+
+	resetDaemonConfigState(t)
+	config.Internal.UnikHome = unikHome
+	daemonConfigFile = explicitFile
+	if err := daemonCmd.Flags().Set("rootless", "true"); err != nil {
+		t.Fatal(err)
+	}
+	daemonConfigFile = writeConfigFile(t, explicitDir, "rootless: false\n")
+
+	if err := readDaemonConfig(daemonCmd.Flags().Lookup("rootless")); err != nil {
+		t.Fatalf("readDaemonConfig() error = %v", err)
+	}
+	if !daemonConfig.Rootless {
+		t.Fatalf("expected --rootless flag to win over config file, got Rootless=false")
+	}
+}
+
+// TestReadDaemonConfig_ProvidersPopulated guards against the mapstructure
+// tag bug: viper.Unmarshal decodes through mapstructure, which ignores
+// yaml tags, so every underscored provider field (vsphere_url,
+// vsphere_password, ...) must carry a matching mapstructure tag or it
+// silently comes back zero-valued.
+func TestReadDaemonConfig_ProvidersPopulated(t *testing.T) {
+	resetDaemonConfigState(t)
+
+	dir, err := ioutil.TempDir("", "unik-providers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeConfigFile(t, dir, `
+providers:
+  vsphere:
+    - vsphere_url: https://vsphere.example.com
+      vsphere_user: admin
+      vsphere_password: hunter2
+      vsphere_datastore: datastore1
+  xen:
+    - xen_bridge: xenbr0
+`)
+	config.Internal.UnikHome = dir
+	daemonConfigFile = ""
+
+	if err := readDaemonConfig(daemonCmd.Flags().Lookup("rootless")); err != nil {
+		t.Fatalf("readDaemonConfig() error = %v", err)
+	}
+
+	if len(daemonConfig.Providers.Vsphere) != 1 {
+		t.Fatalf("expected 1 vsphere block, got %d", len(daemonConfig.Providers.Vsphere))
+	}
+	v := daemonConfig.Providers.Vsphere[0]
+	if v.VsphereURL != "https://vsphere.example.com" {
+		t.Errorf("VsphereURL = %q, want https://vsphere.example.com", v.VsphereURL)
+	}
+	if v.VsphereUser != "admin" {
+		t.Errorf("VsphereUser = %q, want admin", v.VsphereUser)
+	}
+	if v.VspherePassword != "hunter2" {
+		t.Errorf("VspherePassword = %q, want hunter2", v.VspherePassword)
+	}
+	if v.VsphereDatastore != "datastore1" {
+		t.Errorf("VsphereDatastore = %q, want datastore1", v.VsphereDatastore)
+	}
+
+	if len(daemonConfig.Providers.Xen) != 1 {
+		t.Fatalf("expected 1 xen block, got %d", len(daemonConfig.Providers.Xen))
+	}
+	if daemonConfig.Providers.Xen[0].XenBridge != "xenbr0" {
+		t.Errorf("XenBridge = %q, want xenbr0", daemonConfig.Providers.Xen[0].XenBridge)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/solo-io/unik/pkg/config"
+)
+
+// TestReadDaemonConfigThenValidate is an end-to-end check that a vsphere
+// block loaded from a real config file through readDaemonConfig actually
+// passes config.Validate. The validator unit tests alone never exercise
+// the viper/mapstructure decode path, which is exactly where the
+// vsphere_url/vsphere_password fields previously came back empty and
+// failed validation on every correctly-configured daemon.
+func TestReadDaemonConfigThenValidate(t *testing.T) {
+	resetDaemonConfigState(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	dir, err := ioutil.TempDir("", "unik-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeConfigFile(t, dir, `
+providers:
+  vsphere:
+    - vsphere_url: http://`+ln.Addr().String()+`
+      vsphere_user: admin
+      vsphere_password: hunter2
+      vsphere_datastore: datastore1
+`)
+	config.Internal.UnikHome = dir
+	daemonConfigFile = ""
+
+	if err := readDaemonConfig(nil); err != nil {
+		t.Fatalf("readDaemonConfig() error = %v", err)
+	}
+
+	reports := daemonConfig.Validate()
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 validation report, got %d", len(reports))
+	}
+	if reports[0].Provider != "vsphere" {
+		t.Errorf("report.Provider = %q, want vsphere", reports[0].Provider)
+	}
+	if reports[0].Err != nil {
+		t.Errorf("expected vsphere block loaded from file to validate cleanly, got: %v", reports[0].Err)
+	}
+}
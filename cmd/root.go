@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// port is the listening port for the daemon, shared across subcommands
+// that need to know where the daemon is (or will be) listening.
+var port int
+
+// RootCmd is the base command for the unik cli.
+var RootCmd = &cobra.Command{
+	Use:   "unik",
+	Short: "unik builds and manages unikernel images and instances",
+}
+
+// Execute runs the root command, exiting the process on error.
+func Execute() {
+	if err := RootCmd.Execute(); err != nil {
+		logrus.Error(err)
+		os.Exit(-1)
+	}
+}
+
+// getHomeDir returns the calling user's home directory, falling back to
+// the current directory if it cannot be determined.
+func getHomeDir() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		logrus.Warnf("could not determine home directory: %v", err)
+		return "."
+	}
+	return home
+}
@@ -0,0 +1,59 @@
+package config
+
+// Internal holds process-level settings that are resolved from flags and
+// environment rather than read from the daemon config file.
+var Internal = struct {
+	UnikHome string
+}{}
+
+// DaemonConfig is the top level configuration for the unik daemon,
+// typically unmarshalled from a daemon-config.yaml file.
+type DaemonConfig struct {
+	Providers Providers `yaml:"providers"`
+	// Rootless runs the daemon as an unprivileged user, rootlesskit-style.
+	// Providers that require raw root access (of those in Providers, Xen)
+	// refuse to start when this is set.
+	Rootless bool `yaml:"rootless,omitempty"`
+}
+
+// Providers groups the per-provider configuration blocks. A provider
+// counts as configured (see Names and DaemonConfig.Validators) only if its
+// block is present in the config file.
+type Providers struct {
+	Vsphere []VsphereConfig `yaml:"vsphere,omitempty"`
+	Xen     []XenConfig     `yaml:"xen,omitempty"`
+}
+
+// Names returns the name of every provider with at least one configured
+// instance, e.g. for attaching to log output.
+func (p Providers) Names() []string {
+	var names []string
+	if len(p.Vsphere) > 0 {
+		names = append(names, "vsphere")
+	}
+	if len(p.Xen) > 0 {
+		names = append(names, "xen")
+	}
+	return names
+}
+
+// XenConfig holds the connection details for a single Xen provider
+// instance. Xen requires direct access to the hypervisor and cannot run
+// inside a user namespace, so it is refused in rootless mode.
+type XenConfig struct {
+	XenBridge string `yaml:"xen_bridge" mapstructure:"xen_bridge"`
+}
+
+// VsphereConfig holds the connection details for a single vSphere provider
+// instance.
+//
+// Every field carries an explicit mapstructure tag alongside its yaml tag:
+// viper.Unmarshal decodes through mapstructure, which ignores yaml tags and
+// falls back to a case-insensitive match of the whole field name, so
+// "vsphere_url" would never match "VsphereURL" without one.
+type VsphereConfig struct {
+	VsphereURL       string `yaml:"vsphere_url" mapstructure:"vsphere_url"`
+	VsphereUser      string `yaml:"vsphere_user" mapstructure:"vsphere_user"`
+	VspherePassword  string `yaml:"vsphere_password" mapstructure:"vsphere_password" unik:"secret"`
+	VsphereDatastore string `yaml:"vsphere_datastore" mapstructure:"vsphere_datastore"`
+}
@@ -0,0 +1,41 @@
+package config
+
+import "reflect"
+
+// Redactions walks cfg and collects the value of every string field
+// tagged `unik:"secret"`, anywhere in the struct (including nested
+// structs, slices and pointers). It replaces the hard-coded per-provider
+// redaction lists that used to live in cmd.daemonCmd, so new provider
+// config structs are redacted automatically just by tagging their secret
+// fields.
+func Redactions(cfg DaemonConfig) []string {
+	var redactions []string
+	collectSecrets(reflect.ValueOf(cfg), &redactions)
+	return redactions
+}
+
+func collectSecrets(v reflect.Value, out *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectSecrets(v.Elem(), out)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectSecrets(v.Index(i), out)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldVal := v.Field(i)
+			if field.Tag.Get("unik") == "secret" && fieldVal.Kind() == reflect.String {
+				if s := fieldVal.String(); s != "" {
+					*out = append(*out, s)
+				}
+				continue
+			}
+			collectSecrets(fieldVal, out)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestRedactions(t *testing.T) {
+	cfg := DaemonConfig{
+		Providers: Providers{
+			Vsphere: []VsphereConfig{
+				{VsphereURL: "https://vsphere.example.com", VspherePassword: "hunter2"},
+				{VsphereURL: "https://vsphere2.example.com", VspherePassword: ""},
+			},
+			Xen: []XenConfig{
+				{XenBridge: "xenbr0"},
+			},
+		},
+	}
+
+	redactions := Redactions(cfg)
+
+	if len(redactions) != 1 {
+		t.Fatalf("Redactions() = %v, want exactly 1 secret", redactions)
+	}
+	if redactions[0] != "hunter2" {
+		t.Errorf("Redactions()[0] = %q, want %q", redactions[0], "hunter2")
+	}
+}
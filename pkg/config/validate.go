@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// probeTimeout bounds how long a single provider reachability/auth probe
+// is allowed to take, so `unik daemon validate` fails fast instead of
+// hanging on an unreachable endpoint.
+const probeTimeout = 3 * time.Second
+
+// Validator is implemented by each provider's config block to run
+// semantic checks beyond what YAML/JSON/TOML unmarshalling already
+// catches, e.g. reachability of the provider endpoint. New providers
+// plug into `unik daemon validate` by implementing this interface rather
+// than daemon.go growing a new provider-specific branch. A given
+// provider's Validate is free to go further (referenced resources,
+// a credential probe) as its client library allows.
+type Validator interface {
+	// Name identifies which provider block this validator reports under.
+	Name() string
+	// Validate runs the provider's semantic checks, returning the first
+	// failure encountered, or nil if every check passes.
+	Validate() error
+}
+
+// Validators returns a Validator for every provider block configured in
+// cfg.
+func (cfg DaemonConfig) Validators() []Validator {
+	var validators []Validator
+	for i := range cfg.Providers.Vsphere {
+		validators = append(validators, &cfg.Providers.Vsphere[i])
+	}
+	for i := range cfg.Providers.Xen {
+		validators = append(validators, &cfg.Providers.Xen[i])
+	}
+	return validators
+}
+
+// ValidationReport is the pass/fail outcome of validating a single
+// provider block.
+type ValidationReport struct {
+	Provider string
+	Err      error
+}
+
+// Validate runs every configured provider's Validator concurrently, so N
+// unreachable endpoints cost one probeTimeout rather than N, and returns
+// a report for each, in the order the providers appear in cfg.
+func (cfg DaemonConfig) Validate() []ValidationReport {
+	validators := cfg.Validators()
+	reports := make([]ValidationReport, len(validators))
+
+	var wg sync.WaitGroup
+	for i, v := range validators {
+		wg.Add(1)
+		go func(i int, v Validator) {
+			defer wg.Done()
+			reports[i] = ValidationReport{Provider: v.Name(), Err: v.Validate()}
+		}(i, v)
+	}
+	wg.Wait()
+
+	return reports
+}
+
+// Name identifies this block for validation reporting.
+func (c *VsphereConfig) Name() string {
+	return "vsphere"
+}
+
+// Validate checks that the vSphere endpoint is reachable within
+// probeTimeout. It does not attempt a full credential/datastore probe
+// since that requires the govmomi client this config package doesn't
+// depend on; NewUnikDaemon in pkg/daemon does not probe them either.
+func (c *VsphereConfig) Validate() error {
+	if c.VsphereURL == "" {
+		return fmt.Errorf("vsphere_url is required")
+	}
+	u, err := url.Parse(c.VsphereURL)
+	if err != nil {
+		return fmt.Errorf("invalid vsphere_url %q: %v", c.VsphereURL, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid vsphere_url %q: missing scheme (expected e.g. https://...)", c.VsphereURL)
+	}
+	if err := dialReachable(u.Host, u.Scheme); err != nil {
+		return fmt.Errorf("vsphere endpoint %s unreachable: %v", u.Host, err)
+	}
+	return nil
+}
+
+// Name identifies this block for validation reporting.
+func (c *XenConfig) Name() string {
+	return "xen"
+}
+
+// Validate checks that the configured bridge is set; xen is a local
+// hypervisor provider so there's no remote endpoint to probe.
+func (c *XenConfig) Validate() error {
+	if c.XenBridge == "" {
+		return fmt.Errorf("xen_bridge is required")
+	}
+	return nil
+}
+
+func dialReachable(host, scheme string) error {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		switch scheme {
+		case "https":
+			host = net.JoinHostPort(host, "443")
+		default:
+			host = net.JoinHostPort(host, "80")
+		}
+	}
+	conn, err := net.DialTimeout("tcp", host, probeTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/solo-io/unik/pkg/config"
+)
+
+// UnikDaemon holds the set of providers configured for this daemon
+// instance and serves the unik HTTP API on top of them.
+type UnikDaemon struct {
+	configLock sync.RWMutex
+	config     config.DaemonConfig
+	router     *mux.Router
+}
+
+// NewUnikDaemon builds a UnikDaemon from the given configuration and
+// its API router. It stores cfg as is; no provider clients are built.
+//
+// In rootless mode, providers that cannot function inside a user
+// namespace are refused up front with a clear diagnostic rather than
+// failing confusingly on first use. Of the providers in config.Providers,
+// that is Xen.
+func NewUnikDaemon(cfg config.DaemonConfig) (*UnikDaemon, error) {
+	if cfg.Rootless {
+		if len(cfg.Providers.Xen) > 0 {
+			return nil, errors.New("cannot start daemon: xen provider requires root privileges and is not supported in --rootless mode", nil)
+		}
+		if os.Getenv("DOCKER_HOST") == "" {
+			if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+				os.Setenv("DOCKER_HOST", "unix://"+runtimeDir+"/docker.sock")
+			}
+		}
+	}
+
+	d := &UnikDaemon{
+		config: cfg,
+		router: mux.NewRouter(),
+	}
+	d.router.Use(correlationIDMiddleware)
+	return d, nil
+}
+
+// Reload re-validates privileged-provider rules against cfg and, if they
+// pass, swaps it in as the daemon's current configuration under a lock -
+// it does not re-instantiate provider clients, since none are built yet
+// in this package; callers that read d.config (e.g. listener) always see
+// either the old or the new value, never a partial update, and in-flight
+// requests are unaffected either way. It is safe to call concurrently
+// with Run.
+func (d *UnikDaemon) Reload(cfg config.DaemonConfig) error {
+	if cfg.Rootless && len(cfg.Providers.Xen) > 0 {
+		return errors.New("cannot reload daemon config: xen provider requires root privileges and is not supported in --rootless mode", nil)
+	}
+
+	d.configLock.Lock()
+	defer d.configLock.Unlock()
+	d.config = cfg
+	logrus.WithField("config", cfg).Info("daemon config reloaded")
+	return nil
+}
+
+// Run starts serving the unik API. When cfg.Rootless is set, it listens
+// on a unix socket under the daemon's runtime folder instead of a TCP
+// port, since rootless daemons shouldn't assume they can bind privileged
+// or globally-routable addresses; otherwise it falls back to the given
+// TCP port.
+func (d *UnikDaemon) Run(port int) {
+	listener, addr, err := d.listener(port)
+	if err != nil {
+		logrus.Error(errors.New("daemon failed to listen", err))
+		return
+	}
+	logrus.WithField("addr", addr).Info("listening")
+	if err := http.Serve(listener, d.router); err != nil {
+		logrus.Error(errors.New("daemon server exited", err))
+	}
+}
+
+func (d *UnikDaemon) listener(port int) (net.Listener, string, error) {
+	d.configLock.RLock()
+	rootless := d.config.Rootless
+	d.configLock.RUnlock()
+
+	if rootless {
+		socketPath := config.Internal.UnikHome + "/unik.sock"
+		os.Remove(socketPath)
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			return nil, "", errors.New("failed to bind unix socket "+socketPath, err)
+		}
+		return listener, "unix://" + socketPath, nil
+	}
+	addr := fmt.Sprintf(":%d", port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", errors.New("failed to bind tcp address "+addr, err)
+	}
+	return listener, addr, nil
+}
@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/solo-io/unik/pkg/config"
+)
+
+func TestNewUnikDaemon_RootlessRefusesXen(t *testing.T) {
+	cfg := config.DaemonConfig{
+		Rootless: true,
+		Providers: config.Providers{
+			Xen: []config.XenConfig{{XenBridge: "xenbr0"}},
+		},
+	}
+	if _, err := NewUnikDaemon(cfg); err == nil {
+		t.Fatal("expected NewUnikDaemon to refuse a xen provider in rootless mode")
+	}
+}
+
+func TestNewUnikDaemon_RootlessAllowsNonPrivilegedProviders(t *testing.T) {
+	cfg := config.DaemonConfig{
+		Rootless: true,
+		Providers: config.Providers{
+			Vsphere: []config.VsphereConfig{{VsphereURL: "https://vsphere.example.com"}},
+		},
+	}
+	if _, err := NewUnikDaemon(cfg); err != nil {
+		t.Fatalf("NewUnikDaemon() error = %v, want nil", err)
+	}
+}
+
+func TestNewUnikDaemon_RootlessSetsDockerHost(t *testing.T) {
+	origDockerHost, hadDockerHost := os.LookupEnv("DOCKER_HOST")
+	os.Unsetenv("DOCKER_HOST")
+	defer func() {
+		if hadDockerHost {
+			os.Setenv("DOCKER_HOST", origDockerHost)
+		} else {
+			os.Unsetenv("DOCKER_HOST")
+		}
+	}()
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	if _, err := NewUnikDaemon(config.DaemonConfig{Rootless: true}); err != nil {
+		t.Fatalf("NewUnikDaemon() error = %v, want nil", err)
+	}
+	if got, want := os.Getenv("DOCKER_HOST"), "unix:///run/user/1000/docker.sock"; got != want {
+		t.Errorf("DOCKER_HOST = %q, want %q", got, want)
+	}
+}
+
+func TestUnikDaemon_Reload_RejectsRootlessXen(t *testing.T) {
+	d, err := NewUnikDaemon(config.DaemonConfig{})
+	if err != nil {
+		t.Fatalf("NewUnikDaemon() error = %v", err)
+	}
+
+	err = d.Reload(config.DaemonConfig{
+		Rootless: true,
+		Providers: config.Providers{
+			Xen: []config.XenConfig{{XenBridge: "xenbr0"}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected Reload to refuse a rootless+xen combination")
+	}
+}
+
+func TestUnikDaemon_Reload_AppliesValidConfig(t *testing.T) {
+	d, err := NewUnikDaemon(config.DaemonConfig{})
+	if err != nil {
+		t.Fatalf("NewUnikDaemon() error = %v", err)
+	}
+
+	reloaded := config.DaemonConfig{Rootless: true}
+	if err := d.Reload(reloaded); err != nil {
+		t.Fatalf("Reload() error = %v, want nil", err)
+	}
+
+	d.configLock.RLock()
+	got := d.config.Rootless
+	d.configLock.RUnlock()
+	if !got {
+		t.Error("expected Reload to swap in the new config")
+	}
+}
+
+func TestUnikDaemon_listener_RootlessUsesUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	origHome := config.Internal.UnikHome
+	config.Internal.UnikHome = dir
+	defer func() { config.Internal.UnikHome = origHome }()
+
+	d, err := NewUnikDaemon(config.DaemonConfig{Rootless: true})
+	if err != nil {
+		t.Fatalf("NewUnikDaemon() error = %v", err)
+	}
+
+	ln, addr, err := d.listener(0)
+	if err != nil {
+		t.Fatalf("listener() error = %v", err)
+	}
+	defer ln.Close()
+
+	if _, ok := ln.(*net.UnixListener); !ok {
+		t.Errorf("listener() = %T, want *net.UnixListener", ln)
+	}
+	if !strings.HasPrefix(addr, "unix://") {
+		t.Errorf("addr = %q, want unix:// prefix", addr)
+	}
+}
+
+func TestUnikDaemon_listener_DefaultsToTCP(t *testing.T) {
+	d, err := NewUnikDaemon(config.DaemonConfig{})
+	if err != nil {
+		t.Fatalf("NewUnikDaemon() error = %v", err)
+	}
+
+	ln, addr, err := d.listener(0)
+	if err != nil {
+		t.Fatalf("listener() error = %v", err)
+	}
+	defer ln.Close()
+
+	if _, ok := ln.(*net.TCPListener); !ok {
+		t.Errorf("listener() = %T, want *net.TCPListener", ln)
+	}
+	if strings.HasPrefix(addr, "unix://") {
+		t.Errorf("addr = %q, want a TCP address", addr)
+	}
+}
@@ -0,0 +1,34 @@
+package daemon
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// requestIDHeader carries the per-request correlation ID, both inbound
+// (if a caller already has one, e.g. from an upstream proxy) and outbound
+// on the response, so a request can be traced across process boundaries.
+const requestIDHeader = "X-Request-Id"
+
+// correlationIDMiddleware assigns every inbound request a correlation ID,
+// reusing one the caller supplied, and logs the request with it attached
+// so daemon logs for a single API call can be grepped out of the stream.
+func correlationIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		logrus.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"method":     r.Method,
+			"path":       r.URL.Path,
+		}).Info("handling request")
+
+		next.ServeHTTP(w, r)
+	})
+}
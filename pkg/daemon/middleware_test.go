@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorrelationIDMiddleware_GeneratesID(t *testing.T) {
+	var seenByHandler string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenByHandler = w.Header().Get(requestIDHeader)
+	})
+
+	req := httptest.NewRequest("GET", "/instances", nil)
+	rec := httptest.NewRecorder()
+	correlationIDMiddleware(next).ServeHTTP(rec, req)
+
+	respID := rec.Header().Get(requestIDHeader)
+	if respID == "" {
+		t.Fatal("expected a generated request id on the response")
+	}
+	if seenByHandler != respID {
+		t.Errorf("handler saw request id %q, response carries %q", seenByHandler, respID)
+	}
+}
+
+func TestCorrelationIDMiddleware_PreservesSuppliedID(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/instances", nil)
+	req.Header.Set(requestIDHeader, "caller-supplied-id")
+	rec := httptest.NewRecorder()
+	correlationIDMiddleware(next).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(requestIDHeader); got != "caller-supplied-id" {
+		t.Errorf("requestIDHeader = %q, want caller-supplied-id", got)
+	}
+}
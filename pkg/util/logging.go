@@ -0,0 +1,126 @@
+package util
+
+import (
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedTextFormatter wraps another logrus formatter (text, JSON, or
+// otherwise), scrubbing any configured secret strings (e.g. provider
+// passwords) out of the already-serialized bytes before they're written.
+// Redacting the serialized form rather than entry.Message keeps this
+// formatter agnostic to which underlying formatter produced it. Inner
+// defaults to logrus's TextFormatter if left nil.
+type RedactedTextFormatter struct {
+	Inner      logrus.Formatter
+	Redactions []string
+}
+
+// Format renders the entry with the inner formatter, then replaces every
+// occurrence of a redacted string with asterisks.
+func (f *RedactedTextFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	inner := f.Inner
+	if inner == nil {
+		inner = &logrus.TextFormatter{}
+	}
+	data, err := inner.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	line := string(data)
+	for _, redaction := range f.Redactions {
+		if redaction == "" {
+			continue
+		}
+		line = strings.Replace(line, redaction, "****", -1)
+	}
+	return []byte(line), nil
+}
+
+// TeeHook writes every log entry's formatted message to an additional
+// writer, on top of whatever logrus's own output is set to. Writer is
+// commonly a rotating file writer so long-running daemons don't fill
+// the disk.
+type TeeHook struct {
+	Writer io.Writer
+}
+
+// Levels reports that TeeHook fires for every log level.
+func (h *TeeHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire writes the entry's formatted message to the tee'd writer.
+func (h *TeeHook) Fire(entry *logrus.Entry) error {
+	line, err := entry.String()
+	if err != nil {
+		return err
+	}
+	_, err = h.Writer.Write([]byte(line))
+	return err
+}
+
+// StaticFieldsHook attaches the same set of fields to every log entry,
+// e.g. the daemon version, pid and configured provider list, without
+// having to thread them through every call site that logs. The fields
+// aren't actually static over the daemon's lifetime - Set lets a config
+// reload refresh them (e.g. the provider list, if the reload changed
+// which providers are configured) without racing concurrent Fire calls.
+type StaticFieldsHook struct {
+	mu     sync.RWMutex
+	fields logrus.Fields
+}
+
+// NewStaticFieldsHook creates a StaticFieldsHook seeded with fields.
+func NewStaticFieldsHook(fields logrus.Fields) *StaticFieldsHook {
+	return &StaticFieldsHook{fields: fields}
+}
+
+// Levels reports that StaticFieldsHook fires for every log level.
+func (h *StaticFieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire copies the hook's current fields into the entry if they aren't
+// already set.
+func (h *StaticFieldsHook) Fire(entry *logrus.Entry) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for k, v := range h.fields {
+		if _, exists := entry.Data[k]; !exists {
+			entry.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// Set replaces the hook's fields wholesale, e.g. after a config reload
+// changes the configured provider set.
+func (h *StaticFieldsHook) Set(fields logrus.Fields) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fields = fields
+}
+
+// AddTraceHook adds a full stack trace field to every log entry when
+// enabled.
+type AddTraceHook struct {
+	Enabled bool
+}
+
+// Levels reports that AddTraceHook fires for every log level.
+func (h *AddTraceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire attaches the current goroutine's stack trace to the entry.
+func (h *AddTraceHook) Fire(entry *logrus.Entry) error {
+	if h.Enabled {
+		entry.Data["trace"] = string(debug.Stack())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactedTextFormatter_RedactsUnderEitherFormatter(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		inner logrus.Formatter
+	}{
+		{"text", &logrus.TextFormatter{DisableTimestamp: true}},
+		{"json", &logrus.JSONFormatter{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &RedactedTextFormatter{Inner: tc.inner, Redactions: []string{"hunter2"}}
+			entry := logrus.NewEntry(logrus.New())
+			entry.Message = "connecting with password hunter2"
+
+			data, err := f.Format(entry)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if strings.Contains(string(data), "hunter2") {
+				t.Errorf("expected secret to be redacted, got: %s", data)
+			}
+			if !strings.Contains(string(data), "****") {
+				t.Errorf("expected a redaction marker in output, got: %s", data)
+			}
+		})
+	}
+}
+
+func TestRedactedTextFormatter_DefaultsInnerToText(t *testing.T) {
+	f := &RedactedTextFormatter{}
+	entry := logrus.NewEntry(logrus.New())
+	entry.Message = "hello"
+
+	if _, err := f.Format(entry); err != nil {
+		t.Fatalf("Format() error = %v, want nil when Inner is unset", err)
+	}
+}
+
+func TestTeeHook_WritesFormattedEntry(t *testing.T) {
+	var buf bytes.Buffer
+	hook := &TeeHook{Writer: &buf}
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Message = "hello from the daemon"
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "hello from the daemon") {
+		t.Errorf("tee'd output = %q, want it to contain the entry message", buf.String())
+	}
+}
+
+func TestStaticFieldsHook_FireDoesNotOverwriteExistingField(t *testing.T) {
+	hook := NewStaticFieldsHook(logrus.Fields{"provider": "xen"})
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Data["provider"] = "vsphere"
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	if entry.Data["provider"] != "vsphere" {
+		t.Errorf("provider = %v, want the entry's own value to win", entry.Data["provider"])
+	}
+}
+
+func TestStaticFieldsHook_SetRefreshesFields(t *testing.T) {
+	hook := NewStaticFieldsHook(logrus.Fields{"provider": "xen"})
+	hook.Set(logrus.Fields{"provider": "vsphere"})
+
+	entry := logrus.NewEntry(logrus.New())
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	if entry.Data["provider"] != "vsphere" {
+		t.Errorf("provider = %v, want vsphere after Set", entry.Data["provider"])
+	}
+}